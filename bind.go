@@ -0,0 +1,500 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = validator.New()
+
+const defaultTimeLayout = "2.1.2006"
+
+// BindError aggregates every field that failed to bind during a single
+// Bind call, instead of stopping at the first one
+type BindError struct {
+	Errors []error
+}
+
+func (e *BindError) Error() string {
+	msgs := make([]string, len(e.Errors))
+
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("config: %d field(s) failed to bind: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Bind populates dest, a pointer to a struct, by resolving each field
+// through c according to its `config` tag, e.g.
+// `config:"db.host,default=localhost,required"`. Nested structs recurse
+// into the section named by their tag, []string fields are read with
+// MustGetStringSlice, other slices and maps are decoded via
+// UnmarshalSection, and time.Time fields accept a custom parse layout
+// via a sibling `layout` tag (default "2.1.2006", matching the other
+// providers). After every field is populated, struct tags understood by
+// github.com/go-playground/validator are checked. Every invalid or
+// missing required field is collected into a *BindError rather than
+// failing on the first one
+func Bind(c Config, dest interface{}) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config: Bind requires a pointer to a struct, got %T", dest)
+	}
+
+	var errs []error
+
+	bindStruct(c, v.Elem(), &errs)
+
+	if err := validate.Struct(dest); err != nil {
+		if validationErrs, ok := err.(validator.ValidationErrors); ok {
+			for _, fieldErr := range validationErrs {
+				errs = append(errs, fmt.Errorf("%s: %s", fieldErr.Namespace(), fieldErr.Tag()))
+			}
+		} else {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &BindError{Errors: errs}
+	}
+
+	return nil
+}
+
+// OnReload binds dest once and then re-binds it every time watchC fires,
+// calling cb with the value before and after the re-bind. watchC is
+// typically the channel returned by a provider's Watch() method. A
+// failed re-bind is logged and skipped, leaving dest at its last good
+// value
+func OnReload(c Config, dest interface{}, watchC <-chan struct{}, cb func(old, new interface{})) error {
+	if err := Bind(c, dest); err != nil {
+		return err
+	}
+
+	destType := reflect.TypeOf(dest).Elem()
+
+	go func() {
+		for range watchC {
+			old := reflect.ValueOf(dest).Elem().Interface()
+
+			next := reflect.New(destType).Interface()
+			if err := Bind(c, next); err != nil {
+				log.Printf("config: failed to re-bind on reload: %v\n", err)
+
+				continue
+			}
+
+			reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(next).Elem())
+
+			cb(old, reflect.ValueOf(dest).Elem().Interface())
+		}
+	}()
+
+	return nil
+}
+
+func bindStruct(c Config, structVal reflect.Value, errs *[]error) {
+	structType := structVal.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get("config")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		bindField(c, field, structVal.Field(i), tag, errs)
+	}
+}
+
+func bindField(c Config, field reflect.StructField, val reflect.Value, tag string, errs *[]error) {
+	parts := strings.Split(tag, ",")
+	key := parts[0]
+
+	var defaultVal string
+	var hasDefault, required bool
+
+	for _, opt := range parts[1:] {
+		switch {
+		case opt == "required":
+			required = true
+		case strings.HasPrefix(opt, "default="):
+			hasDefault = true
+			defaultVal = strings.TrimPrefix(opt, "default=")
+		}
+	}
+
+	if val.Kind() == reflect.Struct && val.Type() != reflect.TypeOf(time.Time{}) {
+		section, err := c.Section(key)
+		if err != nil {
+			if required || c.Has(key) {
+				*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+			}
+
+			return
+		}
+
+		bindStruct(section, val, errs)
+
+		return
+	}
+
+	parent, lastKey, err := navigate(c, key)
+	if err != nil {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s: %w", key, err))
+		}
+
+		return
+	}
+
+	switch {
+	case val.Type() == reflect.TypeOf(time.Duration(0)):
+		bindDuration(parent, lastKey, key, required, hasDefault, defaultVal, val, errs)
+	case val.Type() == reflect.TypeOf(time.Time{}):
+		layout := field.Tag.Get("layout")
+		if layout == "" {
+			layout = defaultTimeLayout
+		}
+
+		bindTime(parent, lastKey, key, required, hasDefault, defaultVal, layout, val, errs)
+	case val.Kind() == reflect.String:
+		bindString(parent, lastKey, key, required, hasDefault, defaultVal, val, errs)
+	case val.Kind() == reflect.Int || val.Kind() == reflect.Int64:
+		bindInt(parent, lastKey, key, required, hasDefault, defaultVal, val, errs)
+	case val.Kind() == reflect.Uint64:
+		bindUint64(parent, lastKey, key, required, hasDefault, defaultVal, val, errs)
+	case val.Kind() == reflect.Bool:
+		bindBool(parent, lastKey, key, required, hasDefault, defaultVal, val, errs)
+	case val.Kind() == reflect.Slice && val.Type().Elem().Kind() == reflect.String:
+		bindStringSlice(parent, lastKey, key, required, val, errs)
+	default:
+		bindUnmarshal(parent, lastKey, key, required, val, errs)
+	}
+}
+
+// navigate walks every segment of a dotted key but the last through
+// Section, returning the sub-config the final segment should be read
+// from
+func navigate(c Config, key string) (Config, string, error) {
+	segments := strings.Split(key, ".")
+
+	cur := c
+
+	for _, segment := range segments[:len(segments)-1] {
+		next, err := cur.Section(segment)
+		if err != nil {
+			return nil, "", err
+		}
+
+		cur = next
+	}
+
+	return cur, segments[len(segments)-1], nil
+}
+
+func bindString(c Config, key, fullKey string, required, hasDefault bool, defaultVal string, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if hasDefault {
+			val.SetString(defaultVal)
+		}
+
+		return
+	}
+
+	value, err := tryGetString(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.SetString(value)
+}
+
+func bindInt(c Config, key, fullKey string, required, hasDefault bool, defaultVal string, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if !hasDefault {
+			return
+		}
+
+		parsed, err := strconv.ParseInt(defaultVal, 10, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: invalid default %q: %w", fullKey, defaultVal, err))
+
+			return
+		}
+
+		val.SetInt(parsed)
+
+		return
+	}
+
+	value, err := tryGetInt(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.SetInt(int64(value))
+}
+
+func bindUint64(c Config, key, fullKey string, required, hasDefault bool, defaultVal string, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if !hasDefault {
+			return
+		}
+
+		parsed, err := strconv.ParseUint(defaultVal, 10, 64)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: invalid default %q: %w", fullKey, defaultVal, err))
+
+			return
+		}
+
+		val.SetUint(parsed)
+
+		return
+	}
+
+	value, err := tryGetUint64(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.SetUint(value)
+}
+
+func bindBool(c Config, key, fullKey string, required, hasDefault bool, defaultVal string, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if !hasDefault {
+			return
+		}
+
+		parsed, err := strconv.ParseBool(defaultVal)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: invalid default %q: %w", fullKey, defaultVal, err))
+
+			return
+		}
+
+		val.SetBool(parsed)
+
+		return
+	}
+
+	value, err := tryGetBool(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.SetBool(value)
+}
+
+func bindDuration(c Config, key, fullKey string, required, hasDefault bool, defaultVal string, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if !hasDefault {
+			return
+		}
+
+		parsed, err := time.ParseDuration(defaultVal)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: invalid default %q: %w", fullKey, defaultVal, err))
+
+			return
+		}
+
+		val.Set(reflect.ValueOf(parsed))
+
+		return
+	}
+
+	value, err := tryGetDuration(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.Set(reflect.ValueOf(value))
+}
+
+func bindTime(c Config, key, fullKey string, required, hasDefault bool, defaultVal, layout string, val reflect.Value, errs *[]error) {
+	var raw string
+
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+
+			return
+		}
+
+		if !hasDefault {
+			return
+		}
+
+		raw = defaultVal
+	} else {
+		value, err := tryGetString(c, key)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+			return
+		}
+
+		raw = value
+	}
+
+	value, err := time.Parse(layout, raw)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.Set(reflect.ValueOf(value))
+}
+
+func bindStringSlice(c Config, key, fullKey string, required bool, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+		}
+
+		return
+	}
+
+	value, err := tryGetStringSlice(c, key)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+
+		return
+	}
+
+	val.Set(reflect.ValueOf(value))
+}
+
+func bindUnmarshal(c Config, key, fullKey string, required bool, val reflect.Value, errs *[]error) {
+	if !c.Has(key) {
+		if required {
+			*errs = append(*errs, fmt.Errorf("%s is required", fullKey))
+		}
+
+		return
+	}
+
+	if err := c.UnmarshalSection(key, val.Addr().Interface()); err != nil {
+		*errs = append(*errs, fmt.Errorf("%s: %w", fullKey, err))
+	}
+}
+
+// tryGetX calls the matching MustGetX, which we already know c.Has(key),
+// recovering from the panic it would raise for a malformed value and
+// turning it into a regular error so bindX can report it as a bind
+// failure instead of mistaking it for a missing key
+func tryGetString(c Config, key string) (value string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetString(key), nil
+}
+
+func tryGetInt(c Config, key string) (value int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetInt(key), nil
+}
+
+func tryGetUint64(c Config, key string) (value uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetUint64(key), nil
+}
+
+func tryGetBool(c Config, key string) (value bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetBool(key), nil
+}
+
+func tryGetDuration(c Config, key string) (value time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetDuration(key), nil
+}
+
+func tryGetStringSlice(c Config, key string) (value []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return c.MustGetStringSlice(key), nil
+}
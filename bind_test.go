@@ -0,0 +1,367 @@
+package config
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConfig is a minimal in-package Config fixture for bind tests. It
+// avoids depending on any provider package, which would otherwise
+// import this package back and create an import cycle for internal
+// tests
+type fakeConfig struct {
+	values map[string]interface{}
+}
+
+func (c *fakeConfig) Has(key string) bool {
+	_, ok := c.values[key]
+
+	return ok
+}
+
+func (c *fakeConfig) Section(key string) (Config, error) {
+	return nil, fmt.Errorf("section %s was not found in the config", key)
+}
+
+func (c *fakeConfig) SectionAsJSON(key string) (string, error) {
+	return "", fmt.Errorf("section %s was not found in the config", key)
+}
+
+func (c *fakeConfig) UnmarshalSection(key string, dest interface{}) error {
+	return fmt.Errorf("section %s was not found in the config", key)
+}
+
+func (c *fakeConfig) get(key string) (interface{}, error) {
+	value, ok := c.values[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s was not found in the config", key)
+	}
+
+	return value, nil
+}
+
+func (c *fakeConfig) getString(key string) (string, error) {
+	value, err := c.get(key)
+	if err != nil {
+		return "", err
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s is not a string", key)
+	}
+
+	return s, nil
+}
+
+func (c *fakeConfig) getInt(key string) (int, error) {
+	value, err := c.get(key)
+	if err != nil {
+		return 0, err
+	}
+
+	i, ok := value.(int)
+	if !ok {
+		return 0, fmt.Errorf("key %s is not an int", key)
+	}
+
+	return i, nil
+}
+
+func (c *fakeConfig) getDuration(key string) (time.Duration, error) {
+	valueStr, err := c.getString(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.ParseDuration(valueStr)
+}
+
+func (c *fakeConfig) getStringSlice(key string) ([]string, error) {
+	value, err := c.get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := value.([]string)
+	if !ok {
+		return nil, fmt.Errorf("key %s is not a []string", key)
+	}
+
+	return s, nil
+}
+
+func (c *fakeConfig) GetString(key string, defaultVal string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+func (c *fakeConfig) MustGetString(key string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *fakeConfig) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+func (c *fakeConfig) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *fakeConfig) GetUint64(key string, defaultVal uint64) uint64 {
+	return defaultVal
+}
+
+func (c *fakeConfig) MustGetUint64(key string) uint64 {
+	panic(fmt.Errorf("key %s is not a uint64", key))
+}
+
+func (c *fakeConfig) GetTime(key string, defaultVal time.Time) time.Time {
+	return defaultVal
+}
+
+func (c *fakeConfig) MustGetTime(key string) time.Time {
+	panic(fmt.Errorf("key %s is not a time.Time", key))
+}
+
+func (c *fakeConfig) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+func (c *fakeConfig) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *fakeConfig) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+func (c *fakeConfig) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *fakeConfig) GetBool(key string, defaultVal bool) bool {
+	return defaultVal
+}
+
+func (c *fakeConfig) MustGetBool(key string) bool {
+	panic(fmt.Errorf("key %s is not a bool", key))
+}
+
+type bindTarget struct {
+	Name    string        `config:"name"`
+	Port    int           `config:"port,default=8080"`
+	Timeout time.Duration `config:"timeout,default=30s"`
+	Tags    []string      `config:"tags"`
+}
+
+func TestBindPopulatesFromConfig(t *testing.T) {
+	c := &fakeConfig{values: map[string]interface{}{
+		"name":    "qwerty",
+		"port":    9090,
+		"timeout": "1m",
+		"tags":    []string{"a", "b"},
+	}}
+
+	var dest bindTarget
+
+	if err := Bind(c, &dest); err != nil {
+		t.Fatalf("Got err binding: %v", err)
+	}
+
+	if dest.Name != "qwerty" {
+		t.Errorf("Got Name %v, want qwerty", dest.Name)
+	}
+
+	if dest.Port != 9090 {
+		t.Errorf("Got Port %v, want 9090", dest.Port)
+	}
+
+	if dest.Timeout != time.Minute {
+		t.Errorf("Got Timeout %v, want 1m", dest.Timeout)
+	}
+
+	if len(dest.Tags) != 2 || dest.Tags[0] != "a" || dest.Tags[1] != "b" {
+		t.Errorf("Got Tags %v, want [a b]", dest.Tags)
+	}
+}
+
+func TestBindAppliesDefaultWhenMissing(t *testing.T) {
+	c := &fakeConfig{values: map[string]interface{}{
+		"name": "qwerty",
+	}}
+
+	var dest bindTarget
+
+	if err := Bind(c, &dest); err != nil {
+		t.Fatalf("Got err binding: %v", err)
+	}
+
+	if dest.Port != 8080 {
+		t.Errorf("Got Port %v, want default 8080", dest.Port)
+	}
+
+	if dest.Timeout != 30*time.Second {
+		t.Errorf("Got Timeout %v, want default 30s", dest.Timeout)
+	}
+}
+
+func TestBindReportsMalformedValueInsteadOfFallingBackToDefault(t *testing.T) {
+	c := &fakeConfig{values: map[string]interface{}{
+		"name": "qwerty",
+		"port": "not-a-number",
+	}}
+
+	var dest bindTarget
+
+	err := Bind(c, &dest)
+	if err == nil {
+		t.Fatalf("Got no err binding a malformed port, want a BindError instead of a silent default")
+	}
+
+	if dest.Port == 8080 {
+		t.Errorf("Got Port silently set to its default, want the malformed value reported as a bind error")
+	}
+}
+
+func TestBindReportsMissingRequiredField(t *testing.T) {
+	type requiredTarget struct {
+		Name string `config:"name,required"`
+	}
+
+	c := &fakeConfig{values: map[string]interface{}{}}
+
+	var dest requiredTarget
+
+	if err := Bind(c, &dest); err == nil {
+		t.Errorf("Got no err binding a missing required field, want a BindError")
+	}
+}
+
+func TestOnReloadRebindsAndInvokesCallbackOnWatchSignal(t *testing.T) {
+	c := &fakeConfig{values: map[string]interface{}{
+		"name": "v1",
+	}}
+
+	var dest bindTarget
+
+	watchC := make(chan struct{}, 1)
+
+	var mut sync.Mutex
+	var oldGot, newGot interface{}
+
+	cbCalled := make(chan struct{}, 1)
+
+	if err := OnReload(c, &dest, watchC, func(old, new interface{}) {
+		mut.Lock()
+		oldGot = old
+		newGot = new
+		mut.Unlock()
+
+		cbCalled <- struct{}{}
+	}); err != nil {
+		t.Fatalf("Got err from OnReload: %v", err)
+	}
+
+	if dest.Name != "v1" {
+		t.Fatalf("Got Name %v after initial bind, want v1", dest.Name)
+	}
+
+	c.values["name"] = "v2"
+	watchC <- struct{}{}
+
+	select {
+	case <-cbCalled:
+	case <-time.After(time.Second):
+		t.Fatalf("Got no reload callback after watch signal, want one")
+	}
+
+	mut.Lock()
+	defer mut.Unlock()
+
+	if dest.Name != "v2" {
+		t.Errorf("Got Name %v after reload, want v2", dest.Name)
+	}
+
+	old, ok := oldGot.(bindTarget)
+	if !ok || old.Name != "v1" {
+		t.Errorf("Got old %#v, want a bindTarget with Name v1", oldGot)
+	}
+
+	next, ok := newGot.(bindTarget)
+	if !ok || next.Name != "v2" {
+		t.Errorf("Got new %#v, want a bindTarget with Name v2", newGot)
+	}
+}
+
+func TestOnReloadKeepsLastGoodValueOnFailedRebind(t *testing.T) {
+	c := &fakeConfig{values: map[string]interface{}{
+		"name": "v1",
+	}}
+
+	var dest bindTarget
+
+	watchC := make(chan struct{}, 1)
+
+	cbCalled := make(chan struct{}, 1)
+
+	if err := OnReload(c, &dest, watchC, func(old, new interface{}) {
+		cbCalled <- struct{}{}
+	}); err != nil {
+		t.Fatalf("Got err from OnReload: %v", err)
+	}
+
+	c.values["port"] = "not-a-number"
+	watchC <- struct{}{}
+
+	select {
+	case <-cbCalled:
+		t.Fatalf("Got a reload callback for a failed rebind, want none")
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if dest.Name != "v1" {
+		t.Errorf("Got Name %v after a failed rebind, want the last good value v1", dest.Name)
+	}
+}
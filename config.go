@@ -1,10 +1,22 @@
 // Package config holds interface definition for configuration
 package config
 
-import "time"
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
 
 // Config is an interface for configuration implementations
 type Config interface {
+	// Has reports whether key is present at this level of the config,
+	// without regard to whether its value can be decoded into any
+	// particular type
+	Has(key string) bool
 	Section(key string) (Config, error)
 	SectionAsJSON(key string) (string, error)
 	UnmarshalSection(key string, dest interface{}) error
@@ -23,3 +35,92 @@ type Config interface {
 	GetBool(key string, defaultVal bool) bool
 	MustGetBool(key string) bool
 }
+
+// Resolver resolves an interpolation reference found in a config value.
+// For a value like "${vault:secret/data/foo#field}", the resolver
+// registered for scheme "vault" is called with ref "secret/data/foo#field"
+type Resolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// ResolverFunc adapts a plain function to a Resolver
+type ResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	resolversMut sync.RWMutex
+	resolvers    = make(map[string]Resolver)
+)
+
+func init() {
+	RegisterResolver("env", ResolverFunc(func(ref string) (string, error) {
+		return os.Getenv(ref), nil
+	}))
+
+	RegisterResolver("file", ResolverFunc(func(ref string) (string, error) {
+		data, err := ioutil.ReadFile(ref)
+		if err != nil {
+			return "", err
+		}
+
+		return strings.TrimSpace(string(data)), nil
+	}))
+}
+
+// RegisterResolver registers r as the handler for ${scheme:ref} references
+// found in string values read from any provider. Providers call
+// Interpolate on every single string value they return, including those
+// parsed into times and durations, so registering a resolver here is
+// enough to make it available everywhere, regardless of which provider
+// produced the value. The elements returned by the json, yaml and toml
+// providers' string-slice getters are not interpolated
+func RegisterResolver(scheme string, r Resolver) {
+	resolversMut.Lock()
+	defer resolversMut.Unlock()
+
+	resolvers[scheme] = r
+}
+
+var interpolationPattern = regexp.MustCompile(`\$\{(\w+):([^}]+)\}`)
+
+// Interpolate replaces every ${scheme:ref} occurrence in s using the
+// resolver registered for scheme. References whose scheme has no
+// registered resolver are left untouched
+func Interpolate(s string) (string, error) {
+	var firstErr error
+
+	result := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if firstErr != nil {
+			return match
+		}
+
+		groups := interpolationPattern.FindStringSubmatch(match)
+
+		resolversMut.RLock()
+		r, ok := resolvers[groups[1]]
+		resolversMut.RUnlock()
+
+		if !ok {
+			return match
+		}
+
+		resolved, err := r.Resolve(groups[2])
+		if err != nil {
+			firstErr = fmt.Errorf("resolving %s: %w", match, err)
+
+			return match
+		}
+
+		return resolved
+	})
+
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	return result, nil
+}
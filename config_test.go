@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestInterpolateResolvesRegisteredScheme(t *testing.T) {
+	RegisterResolver("test_static", ResolverFunc(func(ref string) (string, error) {
+		return "resolved-" + ref, nil
+	}))
+
+	got, err := Interpolate("prefix-${test_static:foo}-suffix")
+	if err != nil {
+		t.Fatalf("Got err interpolating: %v", err)
+	}
+
+	if want := "prefix-resolved-foo-suffix"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestInterpolateLeavesUnknownSchemeUntouched(t *testing.T) {
+	got, err := Interpolate("${no_such_scheme:foo}")
+	if err != nil {
+		t.Fatalf("Got err interpolating: %v", err)
+	}
+
+	if want := "${no_such_scheme:foo}"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestInterpolateResolvesEnv(t *testing.T) {
+	os.Setenv("CONFIG_TEST_INTERPOLATE_ENV", "envval")
+	defer os.Unsetenv("CONFIG_TEST_INTERPOLATE_ENV")
+
+	got, err := Interpolate("${env:CONFIG_TEST_INTERPOLATE_ENV}")
+	if err != nil {
+		t.Fatalf("Got err interpolating: %v", err)
+	}
+
+	if want := "envval"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestInterpolatePropagatesResolverError(t *testing.T) {
+	RegisterResolver("test_failing", ResolverFunc(func(ref string) (string, error) {
+		return "", errors.New("boom")
+	}))
+
+	if _, err := Interpolate("${test_failing:foo}"); err == nil {
+		t.Errorf("Got no err interpolating a failing resolver, want one")
+	}
+}
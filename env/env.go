@@ -0,0 +1,345 @@
+// Package env implements config.Config backed by OS environment variables.
+// Variable names are mapped to nested config keys by stripping a prefix
+// and treating underscores as path separators, e.g. with prefix "APP"
+// the variable APP_DB_HOST maps to the key "host" under section "db"
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	config "github.com/Darkren/go-config"
+)
+
+// Config represents configuration assembled from environment variables
+// matching a prefix. All values are plain strings parsed on read, since
+// environment variables carry no type information of their own
+type Config struct {
+	c map[string]interface{}
+}
+
+// New scans os.Environ() for variables starting with the upper-cased
+// prefix + "_" and builds a nested config out of their lower-cased names
+// and values
+func New(prefix string) config.Config {
+	root := make(map[string]interface{})
+	p := strings.ToUpper(prefix) + "_"
+
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, p) {
+			continue
+		}
+
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, p)), "_")
+
+		insert(root, path, value)
+	}
+
+	return &Config{c: root}
+}
+
+func insert(root map[string]interface{}, path []string, value string) {
+	node := root
+
+	for _, segment := range path[:len(path)-1] {
+		child, ok := node[segment].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+
+			node[segment] = child
+		}
+
+		node = child
+	}
+
+	node[path[len(path)-1]] = value
+}
+
+// Has reports whether key is present in the config
+func (c *Config) Has(key string) bool {
+	_, ok := c.c[key]
+
+	return ok
+}
+
+// Section returns config section by key. Used for nested objects
+// within configuration
+func (c *Config) Section(key string) (config.Config, error) {
+	section, ok := c.c[key]
+	if !ok {
+		return nil, fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionMap, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("section %s is not an object", key)
+	}
+
+	return &Config{c: sectionMap}, nil
+}
+
+// SectionAsJSON returns config section as JSON string. Used for nested
+// objects within configuration
+func (c *Config) SectionAsJSON(key string) (string, error) {
+	section, ok := c.c[key]
+	if !ok {
+		return "", fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return "", err
+	}
+
+	return string(sectionBytes), nil
+}
+
+// UnmarshalSection unmarshals the section under key into dest. Since
+// environment values are always strings, dest's fields should either be
+// strings or implement json.Unmarshaler, otherwise decoding will fail
+func (c *Config) UnmarshalSection(key string, dest interface{}) error {
+	section, ok := c.c[key]
+	if !ok {
+		return fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(sectionBytes, dest)
+}
+
+// GetString tries to get string value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetString(key string, defaultVal string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetString tries to get string value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetString(key string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetInt tries to get int value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetInt tries to get int value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetUint64(key string, defaultVal uint64) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetUint64(key string) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetTime(key string) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetDuration tries to get time.Duration value by key from configuration.
+// The value must be a valid string to be parsed by standard methods.
+// Returns acquired value or the specified default value
+func (c *Config) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetDuration tries to get time.Duration value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetStringSlice tries to get the string slice value by key from
+// configuration. Values are split on commas. Returns acquired value or
+// the specified default value
+func (c *Config) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetStringSlice tries to get the string slice value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetBool tries to get bool value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetBool(key string, defaultVal bool) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetBool tries to get bool value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetBool(key string) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *Config) getRaw(key string) (string, error) {
+	value, ok := c.c[key]
+	if !ok {
+		return "", fmt.Errorf("key %s was not found in the config", key)
+	}
+
+	valueStr, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s is a section, not a value", key)
+	}
+
+	return config.Interpolate(valueStr)
+}
+
+func (c *Config) getString(key string) (string, error) {
+	return c.getRaw(key)
+}
+
+func (c *Config) getInt(key string) (int, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(valueStr)
+}
+
+func (c *Config) getUint64(key string) (uint64, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(valueStr, 10, 64)
+}
+
+func (c *Config) getTime(key string) (time.Time, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return time.Parse("2.1.2006", valueStr)
+}
+
+func (c *Config) getDuration(key string) (time.Duration, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Nanosecond, err
+	}
+
+	return time.ParseDuration(valueStr)
+}
+
+func (c *Config) getStringSlice(key string) ([]string, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(valueStr, ","), nil
+}
+
+func (c *Config) getBool(key string) (bool, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(valueStr)
+}
@@ -0,0 +1,60 @@
+package env
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNewNestsByUnderscore(t *testing.T) {
+	os.Setenv("ENVTEST_DB_HOST", "localhost")
+	os.Setenv("ENVTEST_DB_PORT", "5432")
+	os.Setenv("ENVTEST_NAME", "qwerty")
+	defer os.Unsetenv("ENVTEST_DB_HOST")
+	defer os.Unsetenv("ENVTEST_DB_PORT")
+	defer os.Unsetenv("ENVTEST_NAME")
+
+	c := New("envtest")
+
+	if v := c.MustGetString("name"); v != "qwerty" {
+		t.Errorf("Got %v, want qwerty", v)
+	}
+
+	db, err := c.Section("db")
+	if err != nil {
+		t.Fatalf("Got err getting db section: %v", err)
+	}
+
+	if v := db.MustGetString("host"); v != "localhost" {
+		t.Errorf("Got %v, want localhost", v)
+	}
+
+	if v := db.MustGetInt("port"); v != 5432 {
+		t.Errorf("Got %v, want 5432", v)
+	}
+}
+
+func TestNewIgnoresNonMatchingPrefix(t *testing.T) {
+	os.Setenv("OTHERPREFIX_NAME", "qwerty")
+	defer os.Unsetenv("OTHERPREFIX_NAME")
+
+	c := New("envtest2")
+
+	if c.Has("name") {
+		t.Errorf("Got Has(name)=true, want false for a variable outside the prefix")
+	}
+}
+
+func TestHas(t *testing.T) {
+	os.Setenv("ENVTEST3_NAME", "qwerty")
+	defer os.Unsetenv("ENVTEST3_NAME")
+
+	c := New("envtest3")
+
+	if !c.Has("name") {
+		t.Errorf("Got Has(name)=false, want true")
+	}
+
+	if c.Has("missing") {
+		t.Errorf("Got Has(missing)=true, want false")
+	}
+}
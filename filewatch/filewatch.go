@@ -0,0 +1,192 @@
+// Package filewatch implements a debounced, rename-safe file watcher
+// shared by the file-based config providers (json, yaml, toml). It
+// watches the target file's parent directory instead of the file
+// itself, since many editors save by writing a temp file and renaming
+// it over the original, which otherwise makes fsnotify stop firing
+// after the first edit. Bursts of events are coalesced by a debounce
+// window before triggering a single reload, and reload notifications
+// fan out to any number of subscribers without blocking on slow readers
+package filewatch
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce is used when no WithDebounce Option is given
+const DefaultDebounce = 100 * time.Millisecond
+
+// ReloadFunc re-reads and re-validates the watched file, swapping it
+// into the caller's state. Returning an error means the swap didn't
+// happen, so the caller's previous state is still the current one
+type ReloadFunc func() error
+
+// Option configures a Watcher created by New
+type Option func(*Watcher)
+
+// WithDebounce overrides the window used to coalesce a burst of
+// filesystem events into a single reload
+func WithDebounce(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
+// WithErrorHandler registers a callback invoked whenever a filesystem
+// error is received or reload returns an error, e.g. because the new
+// document failed to parse
+func WithErrorHandler(f func(error)) Option {
+	return func(w *Watcher) {
+		w.onError = f
+	}
+}
+
+// Watcher watches a single file for changes, debounces bursts of
+// filesystem events, and fans reload notifications out to subscribers
+type Watcher struct {
+	path     string
+	debounce time.Duration
+	reload   ReloadFunc
+	onError  func(error)
+
+	fsw    *fsnotify.Watcher
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mut         sync.Mutex
+	subscribers map[chan struct{}]struct{}
+}
+
+// New starts watching path. After every debounced burst of changes,
+// reload is called; on success, every current subscriber is notified
+func New(path string, reload ReloadFunc, opts ...Option) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fsw.Add(filepath.Dir(path)); err != nil {
+		fsw.Close()
+
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	w := &Watcher{
+		path:        path,
+		debounce:    DefaultDebounce,
+		reload:      reload,
+		fsw:         fsw,
+		cancel:      cancel,
+		done:        make(chan struct{}),
+		subscribers: make(map[chan struct{}]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	go w.run(ctx)
+
+	return w, nil
+}
+
+// Subscribe returns a channel that receives a value after every
+// successful reload. The broadcast to all subscribers never blocks: the
+// channel is buffered by one, so a subscriber that isn't ready to
+// receive right away still sees the pending notification on its next
+// receive instead of losing it, and a subscriber that's already behind
+// simply coalesces bursts into a single pending notification
+func (w *Watcher) Subscribe() <-chan struct{} {
+	ch := make(chan struct{}, 1)
+
+	w.mut.Lock()
+	w.subscribers[ch] = struct{}{}
+	w.mut.Unlock()
+
+	return ch
+}
+
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.done)
+
+	base := filepath.Base(w.path)
+
+	var debounceC <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+
+			if filepath.Base(event.Name) != base {
+				continue
+			}
+
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			debounceC = time.After(w.debounce)
+		case <-debounceC:
+			debounceC = nil
+
+			if err := w.reload(); err != nil {
+				w.handleError(err)
+
+				continue
+			}
+
+			w.broadcast()
+		case err, ok := <-w.fsw.Errors:
+			if !ok {
+				continue
+			}
+
+			w.handleError(err)
+		}
+	}
+}
+
+func (w *Watcher) handleError(err error) {
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+func (w *Watcher) broadcast() {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	for ch := range w.subscribers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close stops the background watch and closes every subscriber channel.
+// It blocks until the watch goroutine has exited
+func (w *Watcher) Close() error {
+	w.cancel()
+	<-w.done
+
+	w.mut.Lock()
+	for ch := range w.subscribers {
+		close(ch)
+		delete(w.subscribers, ch)
+	}
+	w.mut.Unlock()
+
+	return w.fsw.Close()
+}
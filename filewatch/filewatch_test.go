@@ -0,0 +1,176 @@
+package filewatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchNotifiesOnWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Got err writing initial file %v", err)
+	}
+
+	var reloaded int
+
+	w, err := New(path, func() error {
+		reloaded++
+
+		return nil
+	}, WithDebounce(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Got err creating watcher %v", err)
+	}
+	defer w.Close()
+
+	sub := w.Subscribe()
+
+	if err := os.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("Got err writing updated file %v", err)
+	}
+
+	select {
+	case <-sub:
+	case <-time.After(time.Second):
+		t.Fatalf("Got no notification after write, want one")
+	}
+
+	if reloaded != 1 {
+		t.Errorf("Got %d reloads, want 1", reloaded)
+	}
+}
+
+func TestWatchSurvivesRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Got err writing initial file %v", err)
+	}
+
+	w, err := New(path, func() error { return nil }, WithDebounce(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Got err creating watcher %v", err)
+	}
+	defer w.Close()
+
+	sub := w.Subscribe()
+
+	// simulate an editor's save-as-rename: write to a temp file, then
+	// rename it over the original
+	tmp := filepath.Join(dir, "config.json.tmp")
+	if err := os.WriteFile(tmp, []byte("v2"), 0644); err != nil {
+		t.Fatalf("Got err writing temp file %v", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatalf("Got err renaming temp file %v", err)
+	}
+
+	select {
+	case <-sub:
+	case <-time.After(time.Second):
+		t.Fatalf("Got no notification after rename-swap, want one")
+	}
+}
+
+func TestWatchBroadcastsToMultipleSubscribers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Got err writing initial file %v", err)
+	}
+
+	w, err := New(path, func() error { return nil }, WithDebounce(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Got err creating watcher %v", err)
+	}
+	defer w.Close()
+
+	subA := w.Subscribe()
+	subB := w.Subscribe()
+
+	if err := os.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("Got err writing updated file %v", err)
+	}
+
+	for name, sub := range map[string]<-chan struct{}{"A": subA, "B": subB} {
+		select {
+		case <-sub:
+		case <-time.After(time.Second):
+			t.Errorf("Got no notification on subscriber %s, want one", name)
+		}
+	}
+}
+
+func TestWatchSkipsFailedReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Got err writing initial file %v", err)
+	}
+
+	errC := make(chan error, 1)
+
+	w, err := New(path, func() error {
+		return os.ErrInvalid
+	}, WithDebounce(10*time.Millisecond), WithErrorHandler(func(err error) {
+		errC <- err
+	}))
+	if err != nil {
+		t.Fatalf("Got err creating watcher %v", err)
+	}
+	defer w.Close()
+
+	sub := w.Subscribe()
+
+	if err := os.WriteFile(path, []byte("v2"), 0644); err != nil {
+		t.Fatalf("Got err writing updated file %v", err)
+	}
+
+	select {
+	case <-sub:
+		t.Errorf("Got a broadcast for a failed reload, want none")
+	case gotErr := <-errC:
+		if gotErr == nil {
+			t.Errorf("Got no error reported, want the reload error surfaced")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Got no error reported, want the reload error surfaced")
+	}
+}
+
+func TestCloseClosesSubscribers(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+
+	if err := os.WriteFile(path, []byte("v1"), 0644); err != nil {
+		t.Fatalf("Got err writing initial file %v", err)
+	}
+
+	w, err := New(path, func() error { return nil })
+	if err != nil {
+		t.Fatalf("Got err creating watcher %v", err)
+	}
+
+	sub := w.Subscribe()
+
+	if err := w.Close(); err != nil {
+		t.Errorf("Got err closing watcher %v", err)
+	}
+
+	select {
+	case _, ok := <-sub:
+		if ok {
+			t.Errorf("Got a value from subscriber channel, want it closed")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Got no close signal on subscriber channel")
+	}
+}
@@ -0,0 +1,346 @@
+// Package flag implements config.Config backed by command-line flags,
+// parsed with pflag. Flag names support dot-separated nesting, e.g. a
+// flag registered as "db.host" is exposed under section "db" as "host",
+// letting CLI overrides slot into the same layered.Config precedence
+// chain as env and file providers
+package flag
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	config "github.com/Darkren/go-config"
+	"github.com/spf13/pflag"
+)
+
+// Config represents configuration assembled from a parsed pflag.FlagSet.
+// Only flags that were explicitly set on the command line are included,
+// so unset flags fall through to lower-precedence providers
+type Config struct {
+	c map[string]interface{}
+}
+
+// New builds a Config from fs. If fs hasn't been parsed yet, it's parsed
+// against os.Args[1:]
+func New(fs *pflag.FlagSet) (config.Config, error) {
+	if !fs.Parsed() {
+		if err := fs.Parse(os.Args[1:]); err != nil {
+			return nil, err
+		}
+	}
+
+	root := make(map[string]interface{})
+
+	fs.Visit(func(f *pflag.Flag) {
+		path := strings.Split(f.Name, ".")
+
+		insert(root, path, f.Value.String())
+	})
+
+	return &Config{c: root}, nil
+}
+
+func insert(root map[string]interface{}, path []string, value string) {
+	node := root
+
+	for _, segment := range path[:len(path)-1] {
+		child, ok := node[segment].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+
+			node[segment] = child
+		}
+
+		node = child
+	}
+
+	node[path[len(path)-1]] = value
+}
+
+// Has reports whether key is present in the config
+func (c *Config) Has(key string) bool {
+	_, ok := c.c[key]
+
+	return ok
+}
+
+// Section returns config section by key. Used for nested objects
+// within configuration
+func (c *Config) Section(key string) (config.Config, error) {
+	section, ok := c.c[key]
+	if !ok {
+		return nil, fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionMap, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("section %s is not an object", key)
+	}
+
+	return &Config{c: sectionMap}, nil
+}
+
+// SectionAsJSON returns config section as JSON string. Used for nested
+// objects within configuration
+func (c *Config) SectionAsJSON(key string) (string, error) {
+	section, ok := c.c[key]
+	if !ok {
+		return "", fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return "", err
+	}
+
+	return string(sectionBytes), nil
+}
+
+// UnmarshalSection unmarshals the section under key into dest. Since
+// flag values are always strings, dest's fields should either be strings
+// or implement json.Unmarshaler, otherwise decoding will fail
+func (c *Config) UnmarshalSection(key string, dest interface{}) error {
+	section, ok := c.c[key]
+	if !ok {
+		return fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(sectionBytes, dest)
+}
+
+// GetString tries to get string value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetString(key string, defaultVal string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetString tries to get string value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetString(key string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetInt tries to get int value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetInt tries to get int value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetUint64(key string, defaultVal uint64) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetUint64(key string) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetTime(key string) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetDuration tries to get time.Duration value by key from configuration.
+// The value must be a valid string to be parsed by standard methods.
+// Returns acquired value or the specified default value
+func (c *Config) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetDuration tries to get time.Duration value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetStringSlice tries to get the string slice value by key from
+// configuration. Values are split on commas. Returns acquired value or
+// the specified default value
+func (c *Config) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetStringSlice tries to get the string slice value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetBool tries to get bool value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetBool(key string, defaultVal bool) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetBool tries to get bool value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetBool(key string) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *Config) getRaw(key string) (string, error) {
+	value, ok := c.c[key]
+	if !ok {
+		return "", fmt.Errorf("key %s was not found in the config", key)
+	}
+
+	valueStr, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s is a section, not a value", key)
+	}
+
+	return config.Interpolate(valueStr)
+}
+
+func (c *Config) getString(key string) (string, error) {
+	return c.getRaw(key)
+}
+
+func (c *Config) getInt(key string) (int, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(valueStr)
+}
+
+func (c *Config) getUint64(key string) (uint64, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(valueStr, 10, 64)
+}
+
+func (c *Config) getTime(key string) (time.Time, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return time.Parse("2.1.2006", valueStr)
+}
+
+func (c *Config) getDuration(key string) (time.Duration, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Nanosecond, err
+	}
+
+	return time.ParseDuration(valueStr)
+}
+
+func (c *Config) getStringSlice(key string) ([]string, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(valueStr, ","), nil
+}
+
+func (c *Config) getBool(key string) (bool, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(valueStr)
+}
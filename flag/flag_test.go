@@ -0,0 +1,58 @@
+package flag
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestNewNestsByDot(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "", "")
+	fs.String("db.host", "", "")
+	fs.Int("db.port", 0, "")
+
+	if err := fs.Parse([]string{"--name=qwerty", "--db.host=localhost", "--db.port=5432"}); err != nil {
+		t.Fatalf("Got err parsing flags: %v", err)
+	}
+
+	c, err := New(fs)
+	if err != nil {
+		t.Fatalf("Got err building config: %v", err)
+	}
+
+	if v := c.MustGetString("name"); v != "qwerty" {
+		t.Errorf("Got %v, want qwerty", v)
+	}
+
+	db, err := c.Section("db")
+	if err != nil {
+		t.Fatalf("Got err getting db section: %v", err)
+	}
+
+	if v := db.MustGetString("host"); v != "localhost" {
+		t.Errorf("Got %v, want localhost", v)
+	}
+
+	if v := db.MustGetInt("port"); v != 5432 {
+		t.Errorf("Got %v, want 5432", v)
+	}
+}
+
+func TestNewOnlyIncludesFlagsSetOnCommandLine(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "default-name", "")
+
+	if err := fs.Parse(nil); err != nil {
+		t.Fatalf("Got err parsing flags: %v", err)
+	}
+
+	c, err := New(fs)
+	if err != nil {
+		t.Fatalf("Got err building config: %v", err)
+	}
+
+	if c.Has("name") {
+		t.Errorf("Got Has(name)=true, want false for a flag left at its default")
+	}
+}
@@ -3,33 +3,115 @@
 package json
 
 import (
+	"bytes"
+	cryptorand "crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
+	mathrand "math/rand"
+	"os"
+	"strings"
 	"sync"
-	"sync/atomic"
+	"text/template"
 	"time"
 
 	config "github.com/Darkren/go-config"
-	"github.com/fsnotify/fsnotify"
+	"github.com/Darkren/go-config/filewatch"
 )
 
-var (
-	ErrAlreadyBeingWatched = errors.New("config is already being watched")
-	ErrNotBeingWatched     = errors.New("config is not being watched")
-)
+// ErrNotBeingWatched is returned by StopWatching when Watch hasn't been
+// called yet, or has already been stopped
+var ErrNotBeingWatched = errors.New("config is not being watched")
 
 // Config represents data type for configuration
 // parsed from JSON
 type Config struct {
-	mut            sync.RWMutex
-	c              map[string]*json.RawMessage
-	filePath       string
-	isBeingWatched int32
-	watcher        *fsnotify.Watcher
-	watchC         chan struct{}
+	mut      sync.RWMutex
+	c        map[string]*json.RawMessage
+	filePath string
+	debounce time.Duration
+	fw       *filewatch.Watcher
+
+	templateFuncs template.FuncMap
+	templateCache map[string]string
+}
+
+// Option configures a Config created by LoadWithOptions
+type Option func(*Config)
+
+// WithDebounce overrides the window Watch uses to coalesce a burst of
+// filesystem events, e.g. an editor's save-as-rename, into a single
+// reload. Defaults to filewatch.DefaultDebounce
+func WithDebounce(d time.Duration) Option {
+	return func(c *Config) {
+		c.debounce = d
+	}
+}
+
+// WithTemplating enables text/template expansion of string values,
+// evaluated lazily the first time each key is read and cached until the
+// next Watch() reload. funcs is merged on top of a default function map
+// (uuid, now, env, toJSON, trim, add/sub/mul/div, randomInt), letting
+// callers add or override individual functions
+func WithTemplating(funcs template.FuncMap) Option {
+	return func(c *Config) {
+		merged := defaultTemplateFuncs()
+
+		for name, fn := range funcs {
+			merged[name] = fn
+		}
+
+		c.templateFuncs = merged
+		c.templateCache = make(map[string]string)
+	}
+}
+
+func defaultTemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"uuid": newUUID,
+		"now": func() string {
+			return time.Now().Format(time.RFC3339)
+		},
+		"env": os.Getenv,
+		"toJSON": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+
+			return string(b), nil
+		},
+		"trim": strings.TrimSpace,
+		"add": func(a, b int) int {
+			return a + b
+		},
+		"sub": func(a, b int) int {
+			return a - b
+		},
+		"mul": func(a, b int) int {
+			return a * b
+		},
+		"div": func(a, b int) int {
+			return a / b
+		},
+		"randomInt": func(min, max int) int {
+			return min + mathrand.Intn(max-min)
+		},
+	}
+}
+
+func newUUID() string {
+	b := make([]byte, 16)
+
+	// crypto/rand.Read never errors for the standard reader
+	cryptorand.Read(b)
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // New parses JSON string and gets config structure
@@ -40,123 +122,149 @@ func New(jsonStr string) (config.Config, error) {
 // Load reads file from filePath, parses JSON and
 // gets config structure
 func Load(filePath string) (config.Config, error) {
+	return LoadWithOptions(filePath)
+}
+
+// LoadWithOptions reads file from filePath, parses JSON and gets config
+// structure, applying any Option, e.g. WithTemplating
+func LoadWithOptions(filePath string, opts ...Option) (config.Config, error) {
 	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	config := Config{
+	c := Config{
 		filePath: filePath,
+		debounce: filewatch.DefaultDebounce,
 	}
 
-	if err := json.Unmarshal(data, &(config.c)); err != nil {
+	if err := json.Unmarshal(data, &(c.c)); err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c, nil
 }
 
+// Watch starts watching the config file for changes, debounced per
+// WithDebounce, and returns a channel notified after every reload. It
+// can be called more than once: every call gets its own channel, fed by
+// the same underlying watch
 func (c *Config) Watch() (<-chan struct{}, error) {
-	if atomic.CompareAndSwapInt32(&c.isBeingWatched, 0, 1) {
-		watchC := make(chan struct{})
-
-		watcher, err := fsnotify.NewWatcher()
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.fw == nil {
+		fw, err := filewatch.New(c.filePath, c.reload,
+			filewatch.WithDebounce(c.debounce),
+			filewatch.WithErrorHandler(func(err error) {
+				log.Printf("Error reloading config file: %v\n", err)
+			}),
+		)
 		if err != nil {
 			return nil, err
 		}
 
-		c.watcher = watcher
-		c.watchC = watchC
-
-		go func() {
-			for {
-				select {
-				case event, ok := <-watcher.Events:
-					if !ok {
-						return
-					}
-
-					if event.Op&fsnotify.Write == fsnotify.Write {
-						data, err := ioutil.ReadFile(c.filePath)
-						if err != nil {
-							log.Printf("Error reading config file: %v\n", err)
-
-							continue
-						}
-
-						var newData map[string]*json.RawMessage
+		c.fw = fw
+	}
 
-						if err := json.Unmarshal(data, &newData); err != nil {
-							log.Printf("Error unmarshalling config file: %v\n", err)
+	return c.fw.Subscribe(), nil
+}
 
-							continue
-						}
+// reload re-reads and re-parses the config file, only swapping it in if
+// it parses successfully, so a transient write from an editor can never
+// leave the config in a broken state
+func (c *Config) reload() error {
+	data, err := ioutil.ReadFile(c.filePath)
+	if err != nil {
+		return err
+	}
 
-						c.mut.Lock()
+	var newData map[string]*json.RawMessage
 
-						c.c = newData
+	if err := json.Unmarshal(data, &newData); err != nil {
+		return err
+	}
 
-						c.mut.Unlock()
-					}
+	c.mut.Lock()
 
-					<-watcher.Events
+	c.c = newData
 
-					watchC <- struct{}{}
-				case err, ok := <-watcher.Errors:
-					if !ok {
-						continue
-					}
+	if c.templateFuncs != nil {
+		c.templateCache = make(map[string]string)
+	}
 
-					log.Printf("Error receiving fsnotify event: %v\n", err)
-				}
-			}
-		}()
+	c.mut.Unlock()
 
-		return watchC, nil
-	} else {
-		return nil, ErrAlreadyBeingWatched
-	}
+	return nil
 }
 
+// StopWatching stops the watch started by Watch and closes every
+// channel it handed out
 func (c *Config) StopWatching() error {
-	if atomic.CompareAndSwapInt32(&c.isBeingWatched, 1, 0) {
-		err := c.watcher.Close()
+	c.mut.Lock()
+	fw := c.fw
+	c.fw = nil
+	c.mut.Unlock()
 
-		time.Sleep(500 * time.Millisecond)
+	if fw == nil {
+		return ErrNotBeingWatched
+	}
 
-		close(c.watchC)
+	return fw.Close()
+}
 
-		c.watchC = nil
-		c.watcher = nil
+// Has reports whether key is present in the config
+func (c *Config) Has(key string) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
 
-		return err
-	} else {
-		return ErrNotBeingWatched
-	}
+	_, ok := c.c[key]
+
+	return ok
 }
 
 func (c *Config) UnmarshalSection(key string, dest interface{}) error {
-	if _, ok := c.c[key]; !ok {
+	c.mut.RLock()
+
+	raw, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
+
 		return fmt.Errorf("section %s not present in config", key)
 	}
 
-	if err := json.Unmarshal(*(c.c[key]), dest); err != nil {
-		return err
-	}
+	c.mut.RUnlock()
 
-	return nil
+	return json.Unmarshal(*raw, dest)
 }
 
 // Section returns config section by key. Used for nested objects
 // within configuration
 func (c *Config) Section(key string) (config.Config, error) {
-	section := Config{}
+	c.mut.RLock()
+
+	raw, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
 
-	if _, ok := c.c[key]; !ok {
 		return nil, fmt.Errorf("section %s not present in config", key)
 	}
 
-	if err := json.Unmarshal(*(c.c[key]), &(section.c)); err != nil {
+	c.mut.RUnlock()
+
+	section := Config{
+		templateFuncs: c.templateFuncs,
+	}
+
+	if section.templateFuncs != nil {
+		section.templateCache = make(map[string]string)
+	}
+
+	if err := json.Unmarshal(*raw, &(section.c)); err != nil {
 		return nil, err
 	}
 
@@ -259,7 +367,7 @@ func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
 
 // MustGetTime tries to get time.Time value by key from configuration.
 // Returns acquired value or panics in case of any error
-func (c Config) MustGetTime(key string) time.Time {
+func (c *Config) MustGetTime(key string) time.Time {
 	value, err := c.getTime(key)
 	if err != nil {
 		panic(err)
@@ -387,7 +495,47 @@ func (c *Config) getString(key string) (string, error) {
 
 	c.mut.RUnlock()
 
-	return value, nil
+	expanded, err := c.expand(key, value)
+	if err != nil {
+		return "", err
+	}
+
+	return config.Interpolate(expanded)
+}
+
+// expand runs value through the configured text/template function map,
+// if any, caching the result per key until the next Watch() reload
+func (c *Config) expand(key, value string) (string, error) {
+	if c.templateFuncs == nil {
+		return value, nil
+	}
+
+	c.mut.RLock()
+	cached, ok := c.templateCache[key]
+	c.mut.RUnlock()
+
+	if ok {
+		return cached, nil
+	}
+
+	tmpl, err := template.New(key).Funcs(c.templateFuncs).Parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", err
+	}
+
+	expanded := buf.String()
+
+	c.mut.Lock()
+	c.templateCache[key] = expanded
+	c.mut.Unlock()
+
+	return expanded, nil
 }
 
 func (c *Config) getInt(key string) (int, error) {
@@ -1,6 +1,7 @@
 package json
 
 import (
+	"os"
 	"testing"
 	"time"
 )
@@ -117,7 +118,7 @@ func TestGetString(t *testing.T) {
 	}
 
 	// ignoring error - definitely this type
-	Config := config.(Config)
+	Config := config.(*Config)
 
 	name, err := Config.getString("name")
 	if err != nil {
@@ -147,7 +148,7 @@ func TestGetInt(t *testing.T) {
 	}
 
 	// ignoring error - definitely this type
-	Config := config.(Config)
+	Config := config.(*Config)
 
 	id, err := Config.getInt("id")
 	if err != nil {
@@ -177,7 +178,7 @@ func TestGetTime(t *testing.T) {
 	}
 
 	// ignoring error - definitely this type
-	Config := config.(Config)
+	Config := config.(*Config)
 
 	birthday, err := Config.getTime("birthday")
 	if err != nil {
@@ -203,7 +204,7 @@ func TestGetDuration(t *testing.T) {
 	}
 
 	// ignoring error - definitely this type
-	Config := config.(Config)
+	Config := config.(*Config)
 
 	want := 30 * time.Minute
 
@@ -236,7 +237,7 @@ func TestGetStringSlice(t *testing.T) {
 	}
 
 	// ignoring error - definitely this type
-	Config := config.(Config)
+	Config := config.(*Config)
 
 	want := []string{"The Most Brilliant", "Mr Awesome", "Strange Guy"}
 
@@ -250,3 +251,36 @@ func TestGetStringSlice(t *testing.T) {
 		t.Errorf("Got %v, want %v", nicknames, want)
 	}
 }
+
+func TestTemplatingExpandsNestedSections(t *testing.T) {
+	test := `{
+				"greeting": "{{ env \"JSON_TEST_TEMPLATING_NAME\" }}",
+				"server": {
+					"greeting": "{{ env \"JSON_TEST_TEMPLATING_NAME\" }}"
+				}
+			}`
+
+	os.Setenv("JSON_TEST_TEMPLATING_NAME", "qwerty")
+	defer os.Unsetenv("JSON_TEST_TEMPLATING_NAME")
+
+	c, err := newConf([]byte(test))
+	if err != nil {
+		t.Fatalf("Got err parsing %v", test)
+	}
+
+	jc := c.(*Config)
+	WithTemplating(nil)(jc)
+
+	if got := jc.MustGetString("greeting"); got != "qwerty" {
+		t.Errorf("Got %v, want qwerty", got)
+	}
+
+	section, err := jc.Section("server")
+	if err != nil {
+		t.Fatalf("Got err getting section: %v", err)
+	}
+
+	if got := section.MustGetString("greeting"); got != "qwerty" {
+		t.Errorf("Got %v, want qwerty for the nested section, want templateFuncs propagated into Section", got)
+	}
+}
@@ -0,0 +1,384 @@
+// Package layered composes several config.Config providers into a single
+// view with a documented precedence order
+package layered
+
+import (
+	"fmt"
+	"time"
+
+	config "github.com/Darkren/go-config"
+)
+
+// Config merges several providers into a single view. Providers earlier
+// in the list passed to New take precedence over later ones, e.g.
+// layered.New(flags, env, file, defaults) looks up a key in flags first,
+// then env, then file, then defaults
+type Config struct {
+	providers []config.Config
+}
+
+// New composes providers into a single layered Config. Providers are
+// consulted in the order given, and the first one that has a key wins
+func New(providers ...config.Config) config.Config {
+	return &Config{providers: providers}
+}
+
+// Has reports whether key is present in any underlying provider
+func (c *Config) Has(key string) bool {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Section returns a merged view of the sub-section under key across all
+// underlying providers that define it, preserving the same precedence
+// order as the parent config
+func (c *Config) Section(key string) (config.Config, error) {
+	var sections []config.Config
+
+	for _, p := range c.providers {
+		section, err := p.Section(key)
+		if err != nil {
+			continue
+		}
+
+		sections = append(sections, section)
+	}
+
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("section %s not present in config", key)
+	}
+
+	return New(sections...), nil
+}
+
+// SectionAsJSON returns the section as JSON from the highest-precedence
+// provider that defines it. Unlike Section, it does not deep-merge across
+// providers since there's no well-defined way to merge raw JSON documents
+func (c *Config) SectionAsJSON(key string) (string, error) {
+	for _, p := range c.providers {
+		sectionJSON, err := p.SectionAsJSON(key)
+		if err == nil {
+			return sectionJSON, nil
+		}
+	}
+
+	return "", fmt.Errorf("section %s not present in config", key)
+}
+
+// UnmarshalSection unmarshals the section from the highest-precedence
+// provider that defines it. Like SectionAsJSON, it does not deep-merge
+func (c *Config) UnmarshalSection(key string, dest interface{}) error {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return p.UnmarshalSection(key, dest)
+		}
+	}
+
+	return fmt.Errorf("section %s not present in config", key)
+}
+
+// GetString tries to get string value by key, walking providers in
+// precedence order. Returns acquired value or the specified default value
+func (c *Config) GetString(key string, defaultVal string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetString tries to get string value by key, walking providers in
+// precedence order. Returns acquired value or panics in case of any error
+func (c *Config) MustGetString(key string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetInt tries to get int value by key, walking providers in precedence
+// order. Returns acquired value or the specified default value
+func (c *Config) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetInt tries to get int value by key, walking providers in
+// precedence order. Returns acquired value or panics in case of any error
+func (c *Config) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetUint64 tries to get uint64 value by key, walking providers in
+// precedence order. Returns acquired value or the specified default value
+func (c *Config) GetUint64(key string, defaultVal uint64) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetUint64 tries to get uint64 value by key, walking providers in
+// precedence order. Returns acquired value or panics in case of any error
+func (c *Config) MustGetUint64(key string) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetTime tries to get time.Time value by key, walking providers in
+// precedence order. Returns acquired value or the specified default value
+func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetTime tries to get time.Time value by key, walking providers in
+// precedence order. Returns acquired value or panics in case of any error
+func (c *Config) MustGetTime(key string) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetDuration tries to get time.Duration value by key, walking providers
+// in precedence order. Returns acquired value or the specified default value
+func (c *Config) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetDuration tries to get time.Duration value by key, walking
+// providers in precedence order. Returns acquired value or panics in case
+// of any error
+func (c *Config) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetStringSlice tries to get the string slice value by key, walking
+// providers in precedence order. Returns acquired value or the specified
+// default value
+func (c *Config) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetStringSlice tries to get the string slice value by key, walking
+// providers in precedence order. Returns acquired value or panics in case
+// of any error
+func (c *Config) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetBool tries to get bool value by key, walking providers in
+// precedence order. Returns acquired value or the specified default value
+func (c *Config) GetBool(key string, defaultVal bool) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetBool tries to get bool value by key, walking providers in
+// precedence order. Returns acquired value or panics in case of any error
+func (c *Config) MustGetBool(key string) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *Config) getString(key string) (string, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetString(p, key)
+		}
+	}
+
+	return "", fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getInt(key string) (int, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetInt(p, key)
+		}
+	}
+
+	return 0, fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getUint64(key string) (uint64, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetUint64(p, key)
+		}
+	}
+
+	return 0, fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getTime(key string) (time.Time, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetTime(p, key)
+		}
+	}
+
+	return time.Now(), fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getDuration(key string) (time.Duration, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetDuration(p, key)
+		}
+	}
+
+	return time.Nanosecond, fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getStringSlice(key string) ([]string, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetStringSlice(p, key)
+		}
+	}
+
+	return nil, fmt.Errorf("key %s was not found in any provider", key)
+}
+
+func (c *Config) getBool(key string) (bool, error) {
+	for _, p := range c.providers {
+		if p.Has(key) {
+			return tryGetBool(p, key)
+		}
+	}
+
+	return false, fmt.Errorf("key %s was not found in any provider", key)
+}
+
+// tryGetString calls MustGetString on p, which we already know Has(key),
+// converting the panic it would raise for a malformed value into a
+// regular error instead of letting it crash the caller. Unlike a plain
+// "has key" probe, a malformed value is never mistaken for a missing one
+func tryGetString(p config.Config, key string) (value string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetString(key), nil
+}
+
+func tryGetInt(p config.Config, key string) (value int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetInt(key), nil
+}
+
+func tryGetUint64(p config.Config, key string) (value uint64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetUint64(key), nil
+}
+
+func tryGetTime(p config.Config, key string) (value time.Time, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetTime(key), nil
+}
+
+func tryGetDuration(p config.Config, key string) (value time.Duration, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetDuration(key), nil
+}
+
+func tryGetStringSlice(p config.Config, key string) (value []string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetStringSlice(key), nil
+}
+
+func tryGetBool(p config.Config, key string) (value bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return p.MustGetBool(key), nil
+}
@@ -0,0 +1,91 @@
+package layered
+
+import (
+	"testing"
+
+	jsonprovider "github.com/Darkren/go-config/json"
+)
+
+func TestPrecedence(t *testing.T) {
+	high, err := jsonprovider.New(`{"name": "high"}`)
+	if err != nil {
+		t.Fatalf("Got err parsing high provider: %v", err)
+	}
+
+	low, err := jsonprovider.New(`{"name": "low", "id": 1}`)
+	if err != nil {
+		t.Fatalf("Got err parsing low provider: %v", err)
+	}
+
+	c := New(high, low)
+
+	if name := c.MustGetString("name"); name != "high" {
+		t.Errorf("Got %v, expected high-precedence value \"high\"", name)
+	}
+
+	if id := c.MustGetInt("id"); id != 1 {
+		t.Errorf("Got %v, expected fallthrough to low-precedence value 1", id)
+	}
+}
+
+func TestGetStringReturnsDefaultWhenMissingFromAllProviders(t *testing.T) {
+	high, err := jsonprovider.New(`{"name": "high"}`)
+	if err != nil {
+		t.Fatalf("Got err parsing high provider: %v", err)
+	}
+
+	low, err := jsonprovider.New(`{"name": "low"}`)
+	if err != nil {
+		t.Fatalf("Got err parsing low provider: %v", err)
+	}
+
+	c := New(high, low)
+
+	if v := c.GetString("missing", "default"); v != "default" {
+		t.Errorf("Got %v, expected default", v)
+	}
+}
+
+func TestMalformedValueIsNotTreatedAsMissing(t *testing.T) {
+	high, err := jsonprovider.New(`{"port": "not-a-number"}`)
+	if err != nil {
+		t.Fatalf("Got err parsing high provider: %v", err)
+	}
+
+	low, err := jsonprovider.New(`{"port": 8080}`)
+	if err != nil {
+		t.Fatalf("Got err parsing low provider: %v", err)
+	}
+
+	c := New(high, low)
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Got no panic for a malformed value in the highest-precedence provider, expected one instead of silently falling through to the next provider")
+		}
+	}()
+
+	c.MustGetInt("port")
+}
+
+func TestHas(t *testing.T) {
+	high, err := jsonprovider.New(`{"name": "high"}`)
+	if err != nil {
+		t.Fatalf("Got err parsing high provider: %v", err)
+	}
+
+	low, err := jsonprovider.New(`{"id": 1}`)
+	if err != nil {
+		t.Fatalf("Got err parsing low provider: %v", err)
+	}
+
+	c := New(high, low)
+
+	if !c.Has("name") || !c.Has("id") {
+		t.Errorf("Got false for a key present in one of the providers, expected true")
+	}
+
+	if c.Has("missing") {
+		t.Errorf("Got true for a key absent from every provider, expected false")
+	}
+}
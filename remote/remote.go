@@ -0,0 +1,625 @@
+// Package remote implements config.Config backed by a remote KV store
+// (Consul or etcd v3). A key prefix is fetched as the root document and
+// kept nested by treating "/" in key paths as path separators, then
+// pushed through the same Watch() (<-chan struct{}, error) channel used
+// by the file-based providers, letting services in dynamic environments
+// reload without restarts
+package remote
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/Darkren/go-config"
+	consulapi "github.com/hashicorp/consul/api"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+var (
+	ErrAlreadyBeingWatched = errors.New("config is already being watched")
+	ErrNotBeingWatched     = errors.New("config is not being watched")
+)
+
+const defaultWaitTime = 5 * time.Minute
+
+// consulRetryBackoff is how long watchConsul waits before retrying a
+// failed blocking query, so a Consul outage doesn't turn the long-poll
+// into a hot loop hammering the agent
+const consulRetryBackoff = 5 * time.Second
+
+// Option configures a Config created by NewConsul or NewEtcd
+type Option func(*Config)
+
+// WithWaitTime sets the long-poll wait time used for Consul blocking
+// queries. It has no effect on the etcd backend, which watches natively
+func WithWaitTime(d time.Duration) Option {
+	return func(c *Config) {
+		c.waitTime = d
+	}
+}
+
+// Config represents configuration fetched from a remote KV store
+type Config struct {
+	mut    sync.RWMutex
+	c      map[string]interface{}
+	prefix string
+
+	waitTime time.Duration
+
+	consul *consulapi.Client
+	etcd   *clientv3.Client
+
+	isBeingWatched int32
+	watchC         chan struct{}
+	cancelWatch    context.CancelFunc
+	watchDone      chan struct{}
+}
+
+// NewConsul connects to the Consul agent at addr and loads every key
+// under prefix into a nested config document
+func NewConsul(addr, prefix string, opts ...Option) (config.Config, error) {
+	client, err := consulapi.NewClient(&consulapi.Config{Address: addr})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{
+		prefix:   prefix,
+		waitTime: defaultWaitTime,
+		consul:   client,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	tree, _, err := c.fetchConsul(0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.c = tree
+
+	return c, nil
+}
+
+// NewEtcd connects to the etcd cluster at endpoints and loads every key
+// under prefix into a nested config document
+func NewEtcd(endpoints []string, prefix string, opts ...Option) (config.Config, error) {
+	cli, err := clientv3.New(clientv3.Config{Endpoints: endpoints})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Config{
+		prefix:   prefix,
+		waitTime: defaultWaitTime,
+		etcd:     cli,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	tree, err := c.fetchEtcd()
+	if err != nil {
+		return nil, err
+	}
+
+	c.c = tree
+
+	return c, nil
+}
+
+func (c *Config) fetchConsul(waitIndex uint64) (map[string]interface{}, *consulapi.QueryMeta, error) {
+	pairs, meta, err := c.consul.KV().List(c.prefix, &consulapi.QueryOptions{
+		WaitIndex: waitIndex,
+		WaitTime:  c.waitTime,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tree := make(map[string]interface{})
+
+	for _, pair := range pairs {
+		if len(pair.Value) == 0 {
+			continue
+		}
+
+		insert(tree, pathFor(c.prefix, pair.Key), string(pair.Value))
+	}
+
+	return tree, meta, nil
+}
+
+func (c *Config) fetchEtcd() (map[string]interface{}, error) {
+	resp, err := c.etcd.Get(context.Background(), c.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[string]interface{})
+
+	for _, kv := range resp.Kvs {
+		insert(tree, pathFor(c.prefix, string(kv.Key)), string(kv.Value))
+	}
+
+	return tree, nil
+}
+
+func pathFor(prefix, key string) []string {
+	trimmed := strings.TrimPrefix(strings.TrimPrefix(key, prefix), "/")
+
+	return strings.Split(trimmed, "/")
+}
+
+func insert(root map[string]interface{}, path []string, value string) {
+	node := root
+
+	for _, segment := range path[:len(path)-1] {
+		child, ok := node[segment].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+
+			node[segment] = child
+		}
+
+		node = child
+	}
+
+	node[path[len(path)-1]] = value
+}
+
+// Watch starts tracking the remote prefix for changes: long-polling
+// blocking queries for Consul, a native watch for etcd. Each observed
+// change republishes the full prefix into the in-memory tree and signals
+// the returned channel
+func (c *Config) Watch() (<-chan struct{}, error) {
+	if c.etcd != nil {
+		return c.startWatch(c.watchEtcd)
+	}
+
+	return c.startWatch(c.watchConsul)
+}
+
+// startWatch runs loop in a background goroutine, tracking its exit via
+// a done channel so StopWatching can wait for the goroutine to actually
+// stop sending before it closes watchC. Closing watchC out from under a
+// goroutine still in its `watchC <- struct{}{}` select would otherwise
+// race with that send and panic
+func (c *Config) startWatch(loop func(ctx context.Context, watchC chan struct{})) (<-chan struct{}, error) {
+	if !atomic.CompareAndSwapInt32(&c.isBeingWatched, 0, 1) {
+		return nil, ErrAlreadyBeingWatched
+	}
+
+	watchC := make(chan struct{})
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c.mut.Lock()
+	c.watchC = watchC
+	c.cancelWatch = cancel
+	c.watchDone = done
+	c.mut.Unlock()
+
+	go func() {
+		defer close(done)
+
+		loop(ctx, watchC)
+	}()
+
+	return watchC, nil
+}
+
+func (c *Config) watchConsul(ctx context.Context, watchC chan struct{}) {
+	var lastIndex uint64
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		tree, meta, err := c.fetchConsul(lastIndex)
+		if err != nil {
+			log.Printf("Error fetching config from Consul: %v\n", err)
+
+			select {
+			case <-time.After(consulRetryBackoff):
+			case <-ctx.Done():
+				return
+			}
+
+			continue
+		}
+
+		if meta.LastIndex == lastIndex {
+			continue
+		}
+
+		lastIndex = meta.LastIndex
+
+		c.mut.Lock()
+		c.c = tree
+		c.mut.Unlock()
+
+		select {
+		case watchC <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Config) watchEtcd(ctx context.Context, watchC chan struct{}) {
+	wch := c.etcd.Watch(ctx, c.prefix, clientv3.WithPrefix())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case resp, ok := <-wch:
+			if !ok {
+				return
+			}
+
+			if resp.Err() != nil {
+				log.Printf("Error watching etcd prefix: %v\n", resp.Err())
+
+				continue
+			}
+
+			tree, err := c.fetchEtcd()
+			if err != nil {
+				log.Printf("Error fetching config from etcd: %v\n", err)
+
+				continue
+			}
+
+			c.mut.Lock()
+			c.c = tree
+			c.mut.Unlock()
+
+			select {
+			case watchC <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// StopWatching stops the background watch started by Watch and closes
+// the channel it returned
+func (c *Config) StopWatching() error {
+	if !atomic.CompareAndSwapInt32(&c.isBeingWatched, 1, 0) {
+		return ErrNotBeingWatched
+	}
+
+	c.mut.Lock()
+	cancel := c.cancelWatch
+	done := c.watchDone
+	watchC := c.watchC
+	c.mut.Unlock()
+
+	cancel()
+	<-done
+	close(watchC)
+
+	c.mut.Lock()
+	c.watchC = nil
+	c.cancelWatch = nil
+	c.watchDone = nil
+	c.mut.Unlock()
+
+	return nil
+}
+
+// Has reports whether key is present in the config
+func (c *Config) Has(key string) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	_, ok := c.c[key]
+
+	return ok
+}
+
+// Section returns config section by key. Used for nested objects
+// within configuration
+func (c *Config) Section(key string) (config.Config, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	section, ok := c.c[key]
+	if !ok {
+		return nil, fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionMap, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("section %s is not an object", key)
+	}
+
+	return &Config{c: sectionMap}, nil
+}
+
+// SectionAsJSON returns config section as JSON string. Used for nested
+// objects within configuration
+func (c *Config) SectionAsJSON(key string) (string, error) {
+	c.mut.RLock()
+
+	section, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
+
+		return "", fmt.Errorf("section %s not present in config", key)
+	}
+
+	c.mut.RUnlock()
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return "", err
+	}
+
+	return string(sectionBytes), nil
+}
+
+// UnmarshalSection unmarshals the section under key into dest. Leaf
+// values are always strings, as fetched from the KV store, so dest's
+// fields should either be strings or implement json.Unmarshaler
+func (c *Config) UnmarshalSection(key string, dest interface{}) error {
+	c.mut.RLock()
+
+	section, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
+
+		return fmt.Errorf("section %s not present in config", key)
+	}
+
+	c.mut.RUnlock()
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(sectionBytes, dest)
+}
+
+// GetString tries to get string value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetString(key string, defaultVal string) string {
+	value, err := c.getRaw(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetString tries to get string value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetString(key string) string {
+	value, err := c.getRaw(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetInt tries to get int value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetInt tries to get int value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetUint64(key string, defaultVal uint64) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetUint64(key string) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetTime(key string) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetDuration tries to get time.Duration value by key from configuration.
+// The value must be a valid string to be parsed by standard methods.
+// Returns acquired value or the specified default value
+func (c *Config) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetDuration tries to get time.Duration value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetStringSlice tries to get the string slice value by key from
+// configuration. Values are split on commas. Returns acquired value or
+// the specified default value
+func (c *Config) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetStringSlice tries to get the string slice value by key from
+// configuration. Returns acquired value or panics in case of any error
+func (c *Config) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetBool tries to get bool value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetBool(key string, defaultVal bool) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetBool tries to get bool value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetBool(key string) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func (c *Config) getRaw(key string) (string, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	value, ok := c.c[key]
+	if !ok {
+		return "", fmt.Errorf("key %s was not found in the config", key)
+	}
+
+	valueStr, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("key %s is a section, not a value", key)
+	}
+
+	return config.Interpolate(valueStr)
+}
+
+func (c *Config) getInt(key string) (int, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(valueStr)
+}
+
+func (c *Config) getUint64(key string) (uint64, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(valueStr, 10, 64)
+}
+
+func (c *Config) getTime(key string) (time.Time, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	return time.Parse("2.1.2006", valueStr)
+}
+
+func (c *Config) getDuration(key string) (time.Duration, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return time.Nanosecond, err
+	}
+
+	return time.ParseDuration(valueStr)
+}
+
+func (c *Config) getStringSlice(key string) ([]string, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Split(valueStr, ","), nil
+}
+
+func (c *Config) getBool(key string) (bool, error) {
+	valueStr, err := c.getRaw(key)
+	if err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(valueStr)
+}
@@ -0,0 +1,141 @@
+package remote
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPathFor(t *testing.T) {
+	tests := []struct {
+		prefix string
+		key    string
+		want   []string
+	}{
+		{"app/", "app/db/host", []string{"db", "host"}},
+		{"app", "app/db/host", []string{"db", "host"}},
+		{"app/", "app/port", []string{"port"}},
+	}
+
+	for _, tt := range tests {
+		got := pathFor(tt.prefix, tt.key)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pathFor(%q, %q) = %v, want %v", tt.prefix, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestInsertBuildsNestedTree(t *testing.T) {
+	root := make(map[string]interface{})
+
+	insert(root, []string{"db", "host"}, "localhost")
+	insert(root, []string{"db", "port"}, "5432")
+	insert(root, []string{"name"}, "qwerty")
+
+	db, ok := root["db"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Got %v, want a db section", root["db"])
+	}
+
+	if db["host"] != "localhost" {
+		t.Errorf("Got %v, want localhost", db["host"])
+	}
+
+	if db["port"] != "5432" {
+		t.Errorf("Got %v, want 5432", db["port"])
+	}
+
+	if root["name"] != "qwerty" {
+		t.Errorf("Got %v, want qwerty", root["name"])
+	}
+}
+
+func TestGetters(t *testing.T) {
+	c := &Config{
+		c: map[string]interface{}{
+			"name":    "qwerty",
+			"port":    "9090",
+			"enabled": "true",
+			"tags":    "a,b,c",
+		},
+	}
+
+	if v := c.MustGetString("name"); v != "qwerty" {
+		t.Errorf("Got %v, want qwerty", v)
+	}
+
+	if v := c.MustGetInt("port"); v != 9090 {
+		t.Errorf("Got %v, want 9090", v)
+	}
+
+	if v := c.MustGetBool("enabled"); !v {
+		t.Errorf("Got %v, want true", v)
+	}
+
+	if v := c.MustGetStringSlice("tags"); !reflect.DeepEqual(v, []string{"a", "b", "c"}) {
+		t.Errorf("Got %v, want [a b c]", v)
+	}
+
+	if !c.Has("name") || c.Has("missing") {
+		t.Errorf("Got Has(name)=%v, Has(missing)=%v, want true, false", c.Has("name"), c.Has("missing"))
+	}
+}
+
+func TestGetIntReturnsErrorForMalformedValue(t *testing.T) {
+	c := &Config{
+		c: map[string]interface{}{
+			"port": "not-a-number",
+		},
+	}
+
+	if _, err := c.getInt("port"); err == nil {
+		t.Errorf("Got no err getting a malformed int, want one")
+	}
+}
+
+// alwaysSending is a watch loop that keeps trying to notify watchC until
+// ctx is cancelled, used to pin the watch goroutine in its `watchC <-
+// struct{}{}` select for as long as possible, maximizing the chance that
+// a racy StopWatching would close watchC out from under it
+func alwaysSending(ctx context.Context, watchC chan struct{}) {
+	for {
+		select {
+		case watchC <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func TestStopWatchingWaitsForWatchGoroutineBeforeClosing(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		c := &Config{}
+
+		watchC, err := c.startWatch(alwaysSending)
+		if err != nil {
+			t.Fatalf("Got err starting watch: %v", err)
+		}
+
+		go func() {
+			for range watchC {
+			}
+		}()
+
+		if err := c.StopWatching(); err != nil {
+			t.Errorf("Got err stopping watch: %v", err)
+		}
+	}
+}
+
+func TestWatchReturnsErrWhenAlreadyWatching(t *testing.T) {
+	c := &Config{}
+
+	if _, err := c.startWatch(alwaysSending); err != nil {
+		t.Fatalf("Got err starting watch: %v", err)
+	}
+	defer c.StopWatching()
+
+	if _, err := c.startWatch(alwaysSending); err != ErrAlreadyBeingWatched {
+		t.Errorf("Got err %v, want ErrAlreadyBeingWatched", err)
+	}
+}
@@ -0,0 +1,52 @@
+package toml
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeHandlesNativeDateTimeLiteral(t *testing.T) {
+	test := `
+birthday = 1979-05-27
+`
+
+	c, err := newConf([]byte(test))
+	if err != nil {
+		t.Fatalf("Got err parsing %v", test)
+	}
+
+	tc := c.(*Config)
+
+	value, err := tc.getTime("birthday")
+	if err != nil {
+		t.Fatalf("Got err getting birthday: %v", err)
+	}
+
+	want := time.Date(1979, time.May, 27, 0, 0, 0, 0, time.UTC)
+	if !value.Equal(want) {
+		t.Errorf("Got %v, want %v", value, want)
+	}
+}
+
+func TestGetTimeHandlesQuotedStringLayout(t *testing.T) {
+	test := `
+birthday = "27.05.1979"
+`
+
+	c, err := newConf([]byte(test))
+	if err != nil {
+		t.Fatalf("Got err parsing %v", test)
+	}
+
+	tc := c.(*Config)
+
+	value, err := tc.getTime("birthday")
+	if err != nil {
+		t.Fatalf("Got err getting birthday: %v", err)
+	}
+
+	want := time.Date(1979, time.May, 27, 0, 0, 0, 0, time.UTC)
+	if !value.Equal(want) {
+		t.Errorf("Got %v, want %v", value, want)
+	}
+}
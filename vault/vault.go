@@ -0,0 +1,316 @@
+// Package vault implements config.Resolver against HashiCorp Vault's KV
+// v2 secrets engine, so it can be registered to resolve
+// "${vault:secret/data/foo#field}" references via config.RegisterResolver.
+// It authenticates with a token, AppRole, or Kubernetes auth method,
+// caches secrets for the lifetime of their lease, and refreshes them on
+// renewal, surfacing rotations through Watch()
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	config "github.com/Darkren/go-config"
+	vaultapi "github.com/hashicorp/vault/api"
+)
+
+var (
+	ErrAlreadyBeingWatched = errors.New("provider is already being watched")
+	ErrNotBeingWatched     = errors.New("provider is not being watched")
+)
+
+const defaultRenewCheckInterval = 30 * time.Second
+
+// Option configures a Provider created by New
+type Option func(*Provider)
+
+// WithToken authenticates using a static Vault token. This is the
+// default auth method when no other Option is given
+func WithToken(token string) Option {
+	return func(p *Provider) {
+		p.client.SetToken(token)
+	}
+}
+
+// WithAppRole authenticates using the AppRole auth method
+func WithAppRole(roleID, secretID string) Option {
+	return func(p *Provider) {
+		p.login = func() (*vaultapi.Secret, error) {
+			return p.client.Logical().Write("auth/approle/login", map[string]interface{}{
+				"role_id":   roleID,
+				"secret_id": secretID,
+			})
+		}
+	}
+}
+
+// WithKubernetes authenticates using the Kubernetes auth method. jwtPath
+// is the path to the projected service account token, typically
+// "/var/run/secrets/kubernetes.io/serviceaccount/token"
+func WithKubernetes(role, jwtPath string) Option {
+	return func(p *Provider) {
+		p.login = func() (*vaultapi.Secret, error) {
+			jwt, err := ioutil.ReadFile(jwtPath)
+			if err != nil {
+				return nil, err
+			}
+
+			return p.client.Logical().Write("auth/kubernetes/login", map[string]interface{}{
+				"role": role,
+				"jwt":  string(jwt),
+			})
+		}
+	}
+}
+
+// WithRenewCheckInterval overrides how often the background watch loop
+// checks whether a cached secret's lease needs renewing. Defaults to 30s
+func WithRenewCheckInterval(d time.Duration) Option {
+	return func(p *Provider) {
+		p.renewCheckInterval = d
+	}
+}
+
+type cacheEntry struct {
+	data      map[string]interface{}
+	leaseID   string
+	expiresAt time.Time
+}
+
+// Provider resolves ${vault:...} references against a Vault KV v2 mount
+type Provider struct {
+	client *vaultapi.Client
+	login  func() (*vaultapi.Secret, error)
+
+	renewCheckInterval time.Duration
+
+	mut   sync.Mutex
+	cache map[string]cacheEntry
+
+	isBeingWatched int32
+	watchC         chan struct{}
+	cancelWatch    context.CancelFunc
+	watchDone      chan struct{}
+}
+
+// New creates a Provider pointed at the Vault server listening on addr.
+// Without an explicit auth Option it authenticates via WithToken(token)
+func New(addr, token string, opts ...Option) (*Provider, error) {
+	client, err := vaultapi.NewClient(&vaultapi.Config{Address: addr})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Provider{
+		client:             client,
+		renewCheckInterval: defaultRenewCheckInterval,
+		cache:              make(map[string]cacheEntry),
+	}
+
+	WithToken(token)(p)
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.login != nil {
+		if err := p.authenticate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+func (p *Provider) authenticate() error {
+	secret, err := p.login()
+	if err != nil {
+		return err
+	}
+
+	if secret == nil || secret.Auth == nil {
+		return fmt.Errorf("vault: login did not return an auth token")
+	}
+
+	p.client.SetToken(secret.Auth.ClientToken)
+
+	return nil
+}
+
+// Resolve implements config.Resolver. ref must be of the form
+// "mount/data/path#field"
+func (p *Provider) Resolve(ref string) (string, error) {
+	path, field, ok := strings.Cut(ref, "#")
+	if !ok {
+		return "", fmt.Errorf("vault: ref %q must be of the form path#field", ref)
+	}
+
+	data, err := p.readSecret(path)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("vault: field %s not present at %s", field, path)
+	}
+
+	return fmt.Sprintf("%v", value), nil
+}
+
+func (p *Provider) readSecret(path string) (map[string]interface{}, error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if entry, ok := p.cache[path]; ok && time.Now().Before(entry.expiresAt) {
+		return entry.data, nil
+	}
+
+	secret, err := p.client.Logical().Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if secret == nil {
+		return nil, fmt.Errorf("vault: no secret found at %s", path)
+	}
+
+	data, ok := secret.Data["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("vault: %s is not a KV v2 secret", path)
+	}
+
+	leaseDuration := time.Duration(secret.LeaseDuration) * time.Second
+	if leaseDuration == 0 {
+		leaseDuration = defaultRenewCheckInterval
+	}
+
+	p.cache[path] = cacheEntry{
+		data:      data,
+		leaseID:   secret.LeaseID,
+		expiresAt: time.Now().Add(leaseDuration),
+	}
+
+	return data, nil
+}
+
+// Watch starts a background loop that periodically checks every cached
+// secret's lease and re-reads it once it's due for renewal, signalling
+// the returned channel whenever a cached secret rotates
+func (p *Provider) Watch() (<-chan struct{}, error) {
+	return p.startWatch(p.watchLoop)
+}
+
+// startWatch runs loop in a background goroutine, tracking its exit via
+// a done channel so StopWatching can wait for the goroutine to actually
+// stop sending before it closes watchC. Closing watchC out from under a
+// goroutine still in its `watchC <- struct{}{}` select would otherwise
+// race with that send and panic
+func (p *Provider) startWatch(loop func(ctx context.Context, watchC chan struct{})) (<-chan struct{}, error) {
+	if !atomic.CompareAndSwapInt32(&p.isBeingWatched, 0, 1) {
+		return nil, ErrAlreadyBeingWatched
+	}
+
+	watchC := make(chan struct{})
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p.mut.Lock()
+	p.watchC = watchC
+	p.cancelWatch = cancel
+	p.watchDone = done
+	p.mut.Unlock()
+
+	go func() {
+		defer close(done)
+
+		loop(ctx, watchC)
+	}()
+
+	return watchC, nil
+}
+
+func (p *Provider) watchLoop(ctx context.Context, watchC chan struct{}) {
+	ticker := time.NewTicker(p.renewCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if p.refreshExpired() {
+				select {
+				case watchC <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *Provider) refreshExpired() bool {
+	p.mut.Lock()
+	paths := make([]string, 0, len(p.cache))
+
+	for path, entry := range p.cache {
+		if time.Now().After(entry.expiresAt) {
+			paths = append(paths, path)
+		}
+	}
+	p.mut.Unlock()
+
+	var rotated bool
+
+	for _, path := range paths {
+		p.mut.Lock()
+		old := p.cache[path].data
+		p.mut.Unlock()
+
+		data, err := p.readSecret(path)
+		if err == nil && !reflect.DeepEqual(old, data) {
+			rotated = true
+		}
+	}
+
+	return rotated
+}
+
+// StopWatching stops the background renewal loop started by Watch and
+// closes the channel it returned. It waits for the loop to actually
+// exit before closing the channel, since closing it out from under a
+// goroutine still selecting on a send to it would race with that send
+// and panic
+func (p *Provider) StopWatching() error {
+	if !atomic.CompareAndSwapInt32(&p.isBeingWatched, 1, 0) {
+		return ErrNotBeingWatched
+	}
+
+	p.mut.Lock()
+	cancel := p.cancelWatch
+	done := p.watchDone
+	watchC := p.watchC
+	p.mut.Unlock()
+
+	cancel()
+	<-done
+	close(watchC)
+
+	p.mut.Lock()
+	p.watchC = nil
+	p.cancelWatch = nil
+	p.watchDone = nil
+	p.mut.Unlock()
+
+	return nil
+}
+
+var _ config.Resolver = (*Provider)(nil)
@@ -0,0 +1,74 @@
+package vault
+
+import (
+	"context"
+	"testing"
+)
+
+// Resolve's exercise of readSecret against a live Vault server is not
+// covered here, but the ref-format validation that runs before any
+// network call is
+
+func TestResolveRequiresFieldSeparator(t *testing.T) {
+	p, err := New("http://127.0.0.1:0", "fake-token")
+	if err != nil {
+		t.Fatalf("Got err creating provider: %v", err)
+	}
+
+	if _, err := p.Resolve("secret/data/foo"); err == nil {
+		t.Errorf("Got no err resolving a ref without a #field, want one")
+	}
+}
+
+// alwaysSending is a watch loop that keeps trying to notify watchC until
+// ctx is cancelled, used to pin the watch goroutine in its `watchC <-
+// struct{}{}` select for as long as possible, maximizing the chance that
+// a racy StopWatching would close watchC out from under it
+func alwaysSending(ctx context.Context, watchC chan struct{}) {
+	for {
+		select {
+		case watchC <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func TestStopWatchingWaitsForWatchGoroutineBeforeClosing(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		p, err := New("http://127.0.0.1:0", "fake-token")
+		if err != nil {
+			t.Fatalf("Got err creating provider: %v", err)
+		}
+
+		watchC, err := p.startWatch(alwaysSending)
+		if err != nil {
+			t.Fatalf("Got err starting watch: %v", err)
+		}
+
+		go func() {
+			for range watchC {
+			}
+		}()
+
+		if err := p.StopWatching(); err != nil {
+			t.Errorf("Got err stopping watch: %v", err)
+		}
+	}
+}
+
+func TestWatchReturnsErrWhenAlreadyWatching(t *testing.T) {
+	p, err := New("http://127.0.0.1:0", "fake-token")
+	if err != nil {
+		t.Fatalf("Got err creating provider: %v", err)
+	}
+
+	if _, err := p.startWatch(alwaysSending); err != nil {
+		t.Fatalf("Got err starting watch: %v", err)
+	}
+	defer p.StopWatching()
+
+	if _, err := p.startWatch(alwaysSending); err != ErrAlreadyBeingWatched {
+		t.Errorf("Got err %v, want ErrAlreadyBeingWatched", err)
+	}
+}
@@ -0,0 +1,507 @@
+// Package yaml encapsulates structure and methods for
+// parsing and getting values from YAML configuration files
+package yaml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"sync"
+	"time"
+
+	config "github.com/Darkren/go-config"
+	"github.com/Darkren/go-config/filewatch"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ErrNotBeingWatched is returned by StopWatching when Watch hasn't been
+// called yet, or has already been stopped
+var ErrNotBeingWatched = errors.New("config is not being watched")
+
+// Config represents data type for configuration
+// parsed from YAML. The parsed tree is kept as a generic
+// map[string]interface{} and re-marshalled to JSON on demand so
+// it can be consumed the same way as the json provider
+type Config struct {
+	mut      sync.RWMutex
+	c        map[string]interface{}
+	filePath string
+	debounce time.Duration
+	fw       *filewatch.Watcher
+}
+
+// Option configures a Config created by LoadWithOptions
+type Option func(*Config)
+
+// WithDebounce overrides the window Watch uses to coalesce a burst of
+// filesystem events, e.g. an editor's save-as-rename, into a single
+// reload. Defaults to filewatch.DefaultDebounce
+func WithDebounce(d time.Duration) Option {
+	return func(c *Config) {
+		c.debounce = d
+	}
+}
+
+// New parses a YAML string and gets config structure
+func New(yamlStr string) (config.Config, error) {
+	return newConf([]byte(yamlStr))
+}
+
+// Load reads file from filePath, parses YAML and
+// gets config structure
+func Load(filePath string) (config.Config, error) {
+	return LoadWithOptions(filePath)
+}
+
+// LoadWithOptions reads file from filePath, parses YAML and gets config
+// structure, applying any Option, e.g. WithDebounce
+func LoadWithOptions(filePath string, opts ...Option) (config.Config, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	c := Config{
+		filePath: filePath,
+		debounce: filewatch.DefaultDebounce,
+	}
+
+	if err := yamlv3.Unmarshal(data, &(c.c)); err != nil {
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return &c, nil
+}
+
+// Watch starts watching the config file for changes, debounced per
+// WithDebounce, and returns a channel notified after every reload. It
+// can be called more than once: every call gets its own channel, fed by
+// the same underlying watch
+func (c *Config) Watch() (<-chan struct{}, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if c.fw == nil {
+		fw, err := filewatch.New(c.filePath, c.reload,
+			filewatch.WithDebounce(c.debounce),
+			filewatch.WithErrorHandler(func(err error) {
+				log.Printf("Error reloading config file: %v\n", err)
+			}),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		c.fw = fw
+	}
+
+	return c.fw.Subscribe(), nil
+}
+
+// reload re-reads and re-parses the config file, only swapping it in if
+// it parses successfully, so a transient write from an editor can never
+// leave the config in a broken state
+func (c *Config) reload() error {
+	data, err := ioutil.ReadFile(c.filePath)
+	if err != nil {
+		return err
+	}
+
+	var newData map[string]interface{}
+
+	if err := yamlv3.Unmarshal(data, &newData); err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	c.c = newData
+	c.mut.Unlock()
+
+	return nil
+}
+
+// StopWatching stops the watch started by Watch and closes every
+// channel it handed out
+func (c *Config) StopWatching() error {
+	c.mut.Lock()
+	fw := c.fw
+	c.fw = nil
+	c.mut.Unlock()
+
+	if fw == nil {
+		return ErrNotBeingWatched
+	}
+
+	return fw.Close()
+}
+
+// Has reports whether key is present in the config
+func (c *Config) Has(key string) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	_, ok := c.c[key]
+
+	return ok
+}
+
+func (c *Config) UnmarshalSection(key string, dest interface{}) error {
+	c.mut.RLock()
+
+	section, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
+
+		return fmt.Errorf("section %s not present in config", key)
+	}
+
+	c.mut.RUnlock()
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(sectionBytes, dest)
+}
+
+// Section returns config section by key. Used for nested objects
+// within configuration
+func (c *Config) Section(key string) (config.Config, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	section, ok := c.c[key]
+	if !ok {
+		return nil, fmt.Errorf("section %s not present in config", key)
+	}
+
+	sectionMap, ok := section.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("section %s is not an object", key)
+	}
+
+	return &Config{c: sectionMap}, nil
+}
+
+// SectionAsJSON returns config section as JSON string. Used for nested objects
+// within configuration. The section is re-marshalled from the intermediate
+// representation since the source document isn't JSON
+func (c *Config) SectionAsJSON(key string) (string, error) {
+	c.mut.RLock()
+
+	section, ok := c.c[key]
+	if !ok {
+		c.mut.RUnlock()
+
+		return "", fmt.Errorf("section %s not present in config", key)
+	}
+
+	c.mut.RUnlock()
+
+	sectionBytes, err := json.Marshal(section)
+	if err != nil {
+		return "", err
+	}
+
+	return string(sectionBytes), nil
+}
+
+// GetString tries to get string value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetString(key string, defaultVal string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetString tries to get string value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetString(key string) string {
+	value, err := c.getString(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetInt tries to get int value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetInt(key string, defaultVal int) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetInt tries to get int value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetInt(key string) int {
+	value, err := c.getInt(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetUint64(key string, defaultVal uint64) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetUint64 tries to get uint64 value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetUint64(key string) uint64 {
+	value, err := c.getUint64(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetTime(key string, defaultVal time.Time) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetTime tries to get time.Time value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetTime(key string) time.Time {
+	value, err := c.getTime(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetDuration tries to get time.Duration value by key from configuration.
+// The value must be a valid string to be parsed by standard methods. Returns
+// acquired value or the specified default value
+func (c *Config) GetDuration(key string, defaultVal time.Duration) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetDuration tries to get time.Duration value by key from configuration.
+// The value must be a valid string to be parsed by standard methods. Returns
+// acquired value or panics in case of any error
+func (c *Config) MustGetDuration(key string) time.Duration {
+	value, err := c.getDuration(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetStringSlice tries to get the string slice value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetStringSlice(key string, defaultVal []string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetStringSlice tries to get the string slice value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetStringSlice(key string) []string {
+	value, err := c.getStringSlice(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+// GetBool tries to get bool value by key from configuration.
+// Returns acquired value or the specified default value
+func (c *Config) GetBool(key string, defaultVal bool) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		return defaultVal
+	}
+
+	return value
+}
+
+// MustGetBool tries to get bool value by key from configuration.
+// Returns acquired value or panics in case of any error
+func (c *Config) MustGetBool(key string) bool {
+	value, err := c.getBool(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return value
+}
+
+func newConf(yamlData []byte) (config.Config, error) {
+	c := Config{}
+
+	if err := yamlv3.Unmarshal(yamlData, &(c.c)); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+func (c *Config) value(key string) (interface{}, error) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	value, ok := c.c[key]
+	if !ok {
+		return nil, fmt.Errorf("key %s was not found in the config", key)
+	}
+
+	return value, nil
+}
+
+// unmarshalValue re-marshals the value parsed from YAML into JSON and
+// unmarshals it into dest, normalizing type handling across providers
+func (c *Config) unmarshalValue(key string, dest interface{}) error {
+	value, err := c.value(key)
+	if err != nil {
+		return err
+	}
+
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(valueBytes, dest)
+}
+
+func (c *Config) getBool(key string) (bool, error) {
+	var value bool
+
+	if err := c.unmarshalValue(key, &value); err != nil {
+		return false, err
+	}
+
+	return value, nil
+}
+
+func (c *Config) getString(key string) (string, error) {
+	var value string
+
+	if err := c.unmarshalValue(key, &value); err != nil {
+		return "", err
+	}
+
+	return config.Interpolate(value)
+}
+
+func (c *Config) getInt(key string) (int, error) {
+	var value int
+
+	if err := c.unmarshalValue(key, &value); err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+func (c *Config) getUint64(key string) (uint64, error) {
+	var value uint64
+
+	if err := c.unmarshalValue(key, &value); err != nil {
+		return 0, err
+	}
+
+	return value, nil
+}
+
+// getTime accepts both the "2.1.2006" layout the string-keyed getters
+// expect and a YAML timestamp (e.g. an unquoted "birthday: 2018-09-12").
+// yaml.v3 hands such a timestamp to an interface{} target as its source
+// text rather than a time.Time, so a value that doesn't match "2.1.2006"
+// is also tried against the "2006-01-02" and RFC 3339 layouts. A raw
+// time.Time value is returned as is
+func (c *Config) getTime(key string) (time.Time, error) {
+	raw, err := c.value(key)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	if t, ok := raw.(time.Time); ok {
+		return t, nil
+	}
+
+	valueStr, err := c.getString(key)
+	if err != nil {
+		return time.Now(), err
+	}
+
+	value, err := time.Parse("2.1.2006", valueStr)
+	if err != nil {
+		for _, layout := range []string{"2006-01-02", time.RFC3339Nano} {
+			if t, layoutErr := time.Parse(layout, valueStr); layoutErr == nil {
+				return t, nil
+			}
+		}
+
+		return time.Now(), err
+	}
+
+	return value, nil
+}
+
+func (c *Config) getDuration(key string) (time.Duration, error) {
+	valueStr, err := c.getString(key)
+	if err != nil {
+		return time.Nanosecond, err
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return time.Nanosecond, err
+	}
+
+	return value, nil
+}
+
+func (c *Config) getStringSlice(key string) ([]string, error) {
+	var value []string
+
+	if err := c.unmarshalValue(key, &value); err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,52 @@
+package yaml
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeHandlesNativeDateTimeLiteral(t *testing.T) {
+	test := `
+birthday: 2018-09-12
+`
+
+	c, err := newConf([]byte(test))
+	if err != nil {
+		t.Fatalf("Got err parsing %v", test)
+	}
+
+	yc := c.(*Config)
+
+	value, err := yc.getTime("birthday")
+	if err != nil {
+		t.Fatalf("Got err getting birthday: %v", err)
+	}
+
+	want := time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC)
+	if !value.Equal(want) {
+		t.Errorf("Got %v, want %v", value, want)
+	}
+}
+
+func TestGetTimeHandlesQuotedStringLayout(t *testing.T) {
+	test := `
+birthday: "12.09.2018"
+`
+
+	c, err := newConf([]byte(test))
+	if err != nil {
+		t.Fatalf("Got err parsing %v", test)
+	}
+
+	yc := c.(*Config)
+
+	value, err := yc.getTime("birthday")
+	if err != nil {
+		t.Fatalf("Got err getting birthday: %v", err)
+	}
+
+	want := time.Date(2018, time.September, 12, 0, 0, 0, 0, time.UTC)
+	if !value.Equal(want) {
+		t.Errorf("Got %v, want %v", value, want)
+	}
+}